@@ -0,0 +1,197 @@
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+// batchOp is one queued operation in a Batch.
+type batchOp struct {
+	key string
+	del bool
+}
+
+// Batch is a sequence of Put/Delete operations recorded for later atomic
+// application against a CuckooHashTable, in the spirit of goleveldb's
+// WriteBatch: either every queued op lands, or none do.
+type Batch struct {
+	ops []batchOp
+}
+
+// Put queues an insertion of key.
+func (b *Batch) Put(key string) *Batch {
+	b.ops = append(b.ops, batchOp{key: key})
+	return b
+}
+
+// Delete queues a removal of key.
+func (b *Batch) Delete(key string) *Batch {
+	b.ops = append(b.ops, batchOp{key: key, del: true})
+	return b
+}
+
+// Len reports the number of queued operations.
+func (b *Batch) Len() int {
+	return len(b.ops)
+}
+
+// Reset clears the batch so it can be reused.
+func (b *Batch) Reset() {
+	b.ops = b.ops[:0]
+}
+
+// BatchReplay receives the queued operations of a Batch, in order, via
+// Batch.Replay.
+type BatchReplay interface {
+	Put(key string)
+	Delete(key string)
+}
+
+// Replay feeds the batch's queued operations to r, in the order they were
+// queued, without applying them to any table.
+func (b *Batch) Replay(r BatchReplay) {
+	for _, op := range b.ops {
+		if op.del {
+			r.Delete(op.key)
+		} else {
+			r.Put(op.key)
+		}
+	}
+}
+
+// slotWrite records the previous contents of one table slot, so a batch
+// attempt that fails partway through can be undone.
+type slotWrite struct {
+	isTable1 bool
+	position int
+	prevKey  string
+}
+
+// listEdit records an insertion-order list change to apply once a batch is
+// known to have fully committed.
+type listEdit struct {
+	key string
+	add bool
+}
+
+// applyBatch replays b against the live tables and, on success, the
+// insertion-order list, all while holding c.mu for a single critical
+// section. It records undo information so the first failed insertion rolls
+// back everything the batch has done so far. It reports whether the whole
+// batch committed.
+func (c *CuckooHashTable) applyBatch(b *Batch) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var undoLog []slotWrite
+	record := func(isTable1 bool, position int, prevKey string) {
+		undoLog = append(undoLog, slotWrite{isTable1, position, prevKey})
+	}
+	rollback := func() {
+		for i := len(undoLog) - 1; i >= 0; i-- {
+			u := undoLog[i]
+			if u.isTable1 {
+				c.table1[u.position] = u.prevKey
+			} else {
+				c.table2[u.position] = u.prevKey
+			}
+		}
+	}
+
+	var edits []listEdit
+	countDelta := 0
+
+	for _, op := range b.ops {
+		if op.del {
+			pos1 := c.hash1(op.key)
+			if c.table1[pos1] == op.key {
+				record(true, pos1, op.key)
+				c.table1[pos1] = ""
+				edits = append(edits, listEdit{key: op.key})
+				countDelta--
+				continue
+			}
+			pos2 := c.hash2(op.key)
+			if c.table2[pos2] == op.key {
+				record(false, pos2, op.key)
+				c.table2[pos2] = ""
+				edits = append(edits, listEdit{key: op.key})
+				countDelta--
+			}
+			continue
+		}
+
+		pos1 := c.hash1(op.key)
+		if c.table1[pos1] == op.key {
+			continue
+		}
+		pos2 := c.hash2(op.key)
+		if c.table2[pos2] == op.key {
+			continue
+		}
+
+		currentKey := op.key
+		inserted := false
+		for range c.maxKicks {
+			p1 := c.hash1(currentKey)
+			record(true, p1, c.table1[p1])
+			currentKey, c.table1[p1] = c.table1[p1], currentKey
+			if currentKey == "" {
+				inserted = true
+				break
+			}
+
+			p2 := c.hash2(currentKey)
+			record(false, p2, c.table2[p2])
+			currentKey, c.table2[p2] = c.table2[p2], currentKey
+			if currentKey == "" {
+				inserted = true
+				break
+			}
+		}
+
+		if !inserted {
+			rollback()
+			return false
+		}
+
+		edits = append(edits, listEdit{key: op.key, add: true})
+		countDelta++
+	}
+
+	for _, e := range edits {
+		if e.add {
+			if _, exists := c.entries[e.key]; !exists {
+				c.appendEntry(e.key)
+			}
+		} else {
+			c.removeEntry(e.key)
+		}
+	}
+
+	c.count += countDelta
+	return true
+}
+
+// Write applies b to c atomically: either every queued op takes effect or
+// none do. If the tables are too full to fit the batch, Write rehashes and
+// replays the whole batch against the bigger tables rather than committing
+// it partially.
+func (c *CuckooHashTable) Write(b *Batch) error {
+	c.checkMutable("write a batch to")
+
+	backoff := writeBackoffWhileRehashing
+	for c.rehashing.Load() {
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+
+	for range maxTryRehashing {
+		if c.applyBatch(b) {
+			return nil
+		}
+		c.doRehash()
+	}
+
+	return fmt.Errorf("cuckoohashtable: batch of %d ops did not fit after %d rehashes", b.Len(), maxTryRehashing)
+}
@@ -0,0 +1,68 @@
+package main
+
+import "testing"
+
+func TestBatchCommitsAllOps(t *testing.T) {
+	table := NewCuckooHashTable(8)
+	table.Insert("keep")
+	table.Insert("drop")
+
+	var b Batch
+	b.Put("new1").Put("new2").Delete("drop")
+
+	if err := table.Write(&b); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+
+	for _, key := range []string{"keep", "new1", "new2"} {
+		if !table.Contains(key) {
+			t.Errorf("Contains(%q) = false after batch commit", key)
+		}
+	}
+	if table.Contains("drop") {
+		t.Error("Contains(\"drop\") = true after batch deleted it")
+	}
+	if got := table.GetCount(); got != 3 {
+		t.Errorf("GetCount() = %d, want 3", got)
+	}
+}
+
+func TestBatchRollbackRestoresState(t *testing.T) {
+	// doRehash only grows the table to fit what's already persisted, not the
+	// pending batch, so a table starting this small can't grow enough in
+	// maxTryRehashing attempts to fit dozens of new keys: applyBatch is
+	// guaranteed to fail partway through and roll back every attempt.
+	table := NewCuckooHashTable(1)
+	table.Insert("existing")
+
+	before := table.Snapshot()
+
+	var b Batch
+	var newKeys []string
+	for i := range 30 {
+		key := string(rune('a' + i%26))
+		if i >= 26 {
+			key += string(rune('0' + i/26))
+		}
+		newKeys = append(newKeys, key)
+		b.Put(key)
+	}
+
+	err := table.Write(&b)
+	if err == nil {
+		t.Fatal("Write succeeded, want failure for a batch that can't fit")
+	}
+
+	after := table.Snapshot()
+	if before.Len() != after.Len() {
+		t.Fatalf("table count changed across a failed batch: before=%d after=%d", before.Len(), after.Len())
+	}
+	for _, key := range newKeys {
+		if table.Contains(key) {
+			t.Errorf("Contains(%q) = true after a rolled-back batch", key)
+		}
+	}
+	if !table.Contains("existing") {
+		t.Error("Contains(\"existing\") = false after a rolled-back batch")
+	}
+}
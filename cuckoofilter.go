@@ -0,0 +1,242 @@
+package main
+
+import (
+	"hash/fnv"
+	"math/rand"
+	"sync"
+)
+
+const (
+	filterBucketSlots = 4                       // B: fingerprints per bucket
+	filterFPBits      = 12                      // bits per fingerprint
+	filterFPMask      = (1 << filterFPBits) - 1 // 0xFFF
+	maxFilterKicks    = 500                     // eviction attempts before Insert gives up
+)
+
+// CuckooFilter is a cuckoo filter: it stores a small fingerprint of each key
+// instead of the key itself, trading exact membership (and Remove-by-key)
+// for bounded, key-length-independent memory use. Each bucket packs
+// filterBucketSlots fingerprints of filterFPBits bits into a single uint64.
+type CuckooFilter struct {
+	buckets    []uint64 // one packed word per bucket
+	numBuckets int
+	count      int
+	mu         sync.RWMutex
+}
+
+// NewCuckooFilter creates a filter sized to hold roughly capacity items.
+func NewCuckooFilter(capacity int) *CuckooFilter {
+	numBuckets := nextPowerOfTwo(max(1, capacity/filterBucketSlots))
+	return &CuckooFilter{
+		buckets:    make([]uint64, numBuckets),
+		numBuckets: numBuckets,
+	}
+}
+
+func nextPowerOfTwo(n int) int {
+	p := 1
+	for p < n {
+		p *= 2
+	}
+	return p
+}
+
+// hashKey hashes a key with FNV-1a.
+func hashKey(key string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(key))
+	return h.Sum64()
+}
+
+// mix64 is SplitMix64's finalizer. FNV's output bits don't avalanche much
+// beyond the low ones, so slicing two different-but-correlated ranges of a
+// raw FNV hash for the bucket index and the fingerprint reuses much of the
+// same entropy and visibly inflates the false-positive rate; running the
+// hash through a real mixer before carving out the fingerprint bits fixes
+// that.
+func mix64(x uint64) uint64 {
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+	return x
+}
+
+// indexOf derives a key's primary bucket index straight from its hash.
+func indexOf(h uint64, numBuckets int) int {
+	return int(h % uint64(numBuckets))
+}
+
+// fingerprintOf derives a nonzero filterFPBits fingerprint from a key's
+// hash, mixed so it doesn't correlate with indexOf's bits.
+func fingerprintOf(h uint64) uint16 {
+	fp := uint16(mix64(h) & filterFPMask)
+	if fp == 0 {
+		fp = 1
+	}
+	return fp
+}
+
+// hashFingerprint hashes a fingerprint on its own; XORing its result with a
+// bucket index recovers the other of the two candidate buckets for that
+// fingerprint, without ever needing the original key.
+func hashFingerprint(fp uint16) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte{byte(fp), byte(fp >> 8)})
+	return mix64(h.Sum64())
+}
+
+// altIndex returns the other candidate bucket for fp given one of its
+// candidate buckets i. It is its own inverse: altIndex(altIndex(i, fp), fp) == i.
+func (f *CuckooFilter) altIndex(i int, fp uint16) int {
+	return i ^ (int(hashFingerprint(fp)) & (f.numBuckets - 1))
+}
+
+func getFingerprintSlot(word uint64, slot int) uint16 {
+	shift := uint(slot) * filterFPBits
+	return uint16((word >> shift) & filterFPMask)
+}
+
+func setFingerprintSlot(word uint64, slot int, fp uint16) uint64 {
+	shift := uint(slot) * filterFPBits
+	return (word &^ (uint64(filterFPMask) << shift)) | (uint64(fp) << shift)
+}
+
+// bucketFind reports whether bucket i holds fp, and if so, which slot.
+// Empty slots hold 0, which is never a valid fingerprint, so bucketFind(i, 0)
+// locates an empty slot.
+func (f *CuckooFilter) bucketFind(i int, fp uint16) (int, bool) {
+	word := f.buckets[i]
+	for slot := 0; slot < filterBucketSlots; slot++ {
+		if getFingerprintSlot(word, slot) == fp {
+			return slot, true
+		}
+	}
+	return 0, false
+}
+
+// bucketInsert places fp into the first empty slot of bucket i, if any.
+func (f *CuckooFilter) bucketInsert(i int, fp uint16) bool {
+	slot, ok := f.bucketFind(i, 0)
+	if !ok {
+		return false
+	}
+	f.buckets[i] = setFingerprintSlot(f.buckets[i], slot, fp)
+	return true
+}
+
+// Lookup reports whether key may be in the filter. Like all cuckoo filters,
+// it can return false positives but never false negatives.
+func (f *CuckooFilter) Lookup(key string) bool {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	h := hashKey(key)
+	i1 := indexOf(h, f.numBuckets)
+	fp := fingerprintOf(h)
+	i2 := f.altIndex(i1, fp)
+
+	if _, ok := f.bucketFind(i1, fp); ok {
+		return true
+	}
+	_, ok := f.bucketFind(i2, fp)
+	return ok
+}
+
+// Insert adds key's fingerprint to the filter. It tries both candidate
+// buckets' empty slots first, then falls back to evicting a random
+// fingerprint and relocating it to its XOR-alternate bucket, up to
+// maxFilterKicks times.
+//
+// Unlike CuckooHashTable and CuckooMap, a full filter does not rehash or
+// back off: a bucket's alternate is derived from hash(fingerprint), not from
+// the original key, so there is no way to recompute a fingerprint's true
+// candidate buckets at a larger size once the key itself is gone. A false
+// return means the caller must build a bigger filter and re-insert its
+// keys, the same tradeoff other cuckoo filter implementations make.
+func (f *CuckooFilter) Insert(key string) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	h := hashKey(key)
+	i1 := indexOf(h, f.numBuckets)
+	fp := fingerprintOf(h)
+	i2 := f.altIndex(i1, fp)
+
+	if f.bucketInsert(i1, fp) || f.bucketInsert(i2, fp) {
+		f.count++
+		return true
+	}
+
+	i := i1
+	if rand.Intn(2) == 1 {
+		i = i2
+	}
+
+	// Keep track of the kicks so a failed insert can be undone
+	type kick struct{ bucket, slot int }
+	path := make([]kick, 0, maxFilterKicks)
+
+	for range maxFilterKicks {
+		slot := rand.Intn(filterBucketSlots)
+		evicted := getFingerprintSlot(f.buckets[i], slot)
+		f.buckets[i] = setFingerprintSlot(f.buckets[i], slot, fp)
+		path = append(path, kick{bucket: i, slot: slot})
+		fp = evicted
+		i = f.altIndex(i, fp)
+		if f.bucketInsert(i, fp) {
+			f.count++
+			return true
+		}
+	}
+
+	// Insertion failed: walk the kicks back so every fingerprint already in
+	// the filter returns to its slot and only key's own is dropped.
+	for k := len(path) - 1; k >= 0; k-- {
+		p := path[k]
+		placed := getFingerprintSlot(f.buckets[p.bucket], p.slot)
+		f.buckets[p.bucket] = setFingerprintSlot(f.buckets[p.bucket], p.slot, fp)
+		fp = placed
+	}
+
+	return false
+}
+
+// Delete removes one fingerprint matching key from the filter, if present.
+// Because fingerprints can collide, this may occasionally remove a
+// different key's fingerprint instead (a false-positive delete) -- the same
+// limitation Lookup has.
+func (f *CuckooFilter) Delete(key string) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	h := hashKey(key)
+	i1 := indexOf(h, f.numBuckets)
+	fp := fingerprintOf(h)
+	i2 := f.altIndex(i1, fp)
+
+	if slot, ok := f.bucketFind(i1, fp); ok {
+		f.buckets[i1] = setFingerprintSlot(f.buckets[i1], slot, 0)
+		f.count--
+		return true
+	}
+	if slot, ok := f.bucketFind(i2, fp); ok {
+		f.buckets[i2] = setFingerprintSlot(f.buckets[i2], slot, 0)
+		f.count--
+		return true
+	}
+
+	return false
+}
+
+// FalsePositiveRate estimates the filter's current false-positive
+// probability from its fingerprint width and load factor, using the
+// standard cuckoo-filter approximation epsilon ~= loadFactor * 2B / 2^f.
+func (f *CuckooFilter) FalsePositiveRate() float64 {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	loadFactor := float64(f.count) / float64(f.numBuckets*filterBucketSlots)
+	return loadFactor * float64(2*filterBucketSlots) / float64(uint64(1)<<filterFPBits)
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestCuckooFilterInsertLookupDelete(t *testing.T) {
+	f := NewCuckooFilter(16)
+
+	if !f.Insert("hello") {
+		t.Fatal("Insert(hello) failed")
+	}
+	if !f.Lookup("hello") {
+		t.Fatal("Lookup(hello) = false after Insert")
+	}
+	if !f.Delete("hello") {
+		t.Fatal("Delete(hello) returned false for a present key")
+	}
+}
+
+func TestCuckooFilterRoundTripAfterEviction(t *testing.T) {
+	// With enough keys relative to capacity, Insert's kick-eviction path
+	// must run, which relocates already-present fingerprints rather than
+	// just placing new ones in empty slots. Every key inserted along the
+	// way should still be found afterward.
+	f := NewCuckooFilter(64)
+
+	var keys []string
+	for i := range 120 {
+		keys = append(keys, fmt.Sprintf("key-%d", i))
+	}
+
+	var inserted []string
+	for _, key := range keys {
+		if f.Insert(key) {
+			inserted = append(inserted, key)
+		}
+	}
+
+	if len(inserted) == 0 {
+		t.Fatal("no keys were inserted")
+	}
+
+	for _, key := range inserted {
+		if !f.Lookup(key) {
+			t.Errorf("Lookup(%q) = false after eviction-path inserts, want true", key)
+		}
+	}
+}
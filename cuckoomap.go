@@ -0,0 +1,382 @@
+package main
+
+import (
+	"hash/fnv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// bucket is a single slot of a CuckooMap's tables.
+type bucket[K comparable, V any] struct {
+	filled bool
+	key    K
+	value  V
+}
+
+// Hasher supplies the two independent hash functions a CuckooMap needs for
+// its key type. Callers with a custom key type can implement this to avoid
+// relying on the FNV-based defaults.
+type Hasher[K comparable] interface {
+	Hash1(key K) uint64
+	Hash2(key K) uint64
+}
+
+// stringHasher is the default Hasher for string keys.
+type stringHasher struct{}
+
+func (stringHasher) Hash1(key string) uint64 {
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	return uint64(h.Sum32())
+}
+
+func (stringHasher) Hash2(key string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(key))
+	return h.Sum64()
+}
+
+// Integer constrains the default hasher offered for integer-kinded keys.
+type Integer interface {
+	~int | ~int8 | ~int16 | ~int32 | ~int64 |
+		~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 | ~uintptr
+}
+
+// intHasher is the default Hasher for integer-kinded keys. It mixes the
+// key's bits through FNV the same way the string hashers do, rather than
+// using the integer value directly, so small keys still spread across the
+// table.
+type intHasher[K Integer] struct{}
+
+func intBytes[K Integer](key K) []byte {
+	v := uint64(key)
+	return []byte{
+		byte(v), byte(v >> 8), byte(v >> 16), byte(v >> 24),
+		byte(v >> 32), byte(v >> 40), byte(v >> 48), byte(v >> 56),
+	}
+}
+
+func (intHasher[K]) Hash1(key K) uint64 {
+	h := fnv.New32a()
+	h.Write(intBytes(key))
+	return uint64(h.Sum32())
+}
+
+func (intHasher[K]) Hash2(key K) uint64 {
+	h := fnv.New64a()
+	h.Write(intBytes(key))
+	return h.Sum64()
+}
+
+// defaultHasher picks an FNV-based Hasher for string and the predeclared
+// integer types. It panics for any other K, including a named type whose
+// underlying type is one of those; callers with other key types must use
+// NewCuckooMapWithHasher instead.
+func defaultHasher[K comparable]() Hasher[K] {
+	var zero K
+	switch any(zero).(type) {
+	case string:
+		return any(stringHasher{}).(Hasher[K])
+	case int:
+		return any(intHasher[int]{}).(Hasher[K])
+	case int8:
+		return any(intHasher[int8]{}).(Hasher[K])
+	case int16:
+		return any(intHasher[int16]{}).(Hasher[K])
+	case int32:
+		return any(intHasher[int32]{}).(Hasher[K])
+	case int64:
+		return any(intHasher[int64]{}).(Hasher[K])
+	case uint:
+		return any(intHasher[uint]{}).(Hasher[K])
+	case uint8:
+		return any(intHasher[uint8]{}).(Hasher[K])
+	case uint16:
+		return any(intHasher[uint16]{}).(Hasher[K])
+	case uint32:
+		return any(intHasher[uint32]{}).(Hasher[K])
+	case uint64:
+		return any(intHasher[uint64]{}).(Hasher[K])
+	case uintptr:
+		return any(intHasher[uintptr]{}).(Hasher[K])
+	default:
+		panic("cuckoomap: no default Hasher for this key type; use NewCuckooMapWithHasher")
+	}
+}
+
+// CuckooMap is the generic key/value counterpart to CuckooHashTable: two
+// cuckoo tables of buckets instead of one table of bare string keys.
+type CuckooMap[K comparable, V any] struct {
+	table1    []bucket[K, V]
+	table2    []bucket[K, V]
+	size      int
+	maxKicks  int
+	count     int
+	mu        sync.RWMutex
+	rehashing atomic.Bool
+	hasher    Hasher[K]
+}
+
+// NewCuckooMap creates a new cuckoo map of the given size, using the
+// default FNV-based Hasher for K.
+func NewCuckooMap[K comparable, V any](size int) *CuckooMap[K, V] {
+	return NewCuckooMapWithHasher[K, V](size, defaultHasher[K]())
+}
+
+// NewCuckooMapWithHasher creates a new cuckoo map of the given size using a
+// caller-supplied Hasher, for key types with no built-in default.
+func NewCuckooMapWithHasher[K comparable, V any](size int, hasher Hasher[K]) *CuckooMap[K, V] {
+	return &CuckooMap[K, V]{
+		table1:   make([]bucket[K, V], size),
+		table2:   make([]bucket[K, V], size),
+		size:     size,
+		maxKicks: size * 2,
+		hasher:   hasher,
+	}
+}
+
+func (m *CuckooMap[K, V]) hash1(key K) int {
+	return int(m.hasher.Hash1(key) % uint64(m.size))
+}
+
+func (m *CuckooMap[K, V]) hash2(key K) int {
+	return int(m.hasher.Hash2(key) % uint64(m.size))
+}
+
+// Get returns the value stored for key, and whether it was present.
+func (m *CuckooMap[K, V]) Get(key K) (V, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	pos1 := m.hash1(key)
+	if b := m.table1[pos1]; b.filled && b.key == key {
+		return b.value, true
+	}
+
+	pos2 := m.hash2(key)
+	if b := m.table2[pos2]; b.filled && b.key == key {
+		return b.value, true
+	}
+
+	var zero V
+	return zero, false
+}
+
+// putWithoutRehash is a helper function that attempts a put without
+// triggering rehash. The second return value reports whether the put added
+// a brand-new entry, as opposed to updating one that was already present.
+func (m *CuckooMap[K, V]) putWithoutRehash(key K, val V) (ok bool, inserted bool) {
+	for m.rehashing.Load() {
+		time.Sleep(time.Millisecond)
+	}
+
+	pos1 := m.hash1(key)
+	if m.table1[pos1].filled && m.table1[pos1].key == key {
+		m.table1[pos1].value = val
+		return true, false
+	}
+
+	pos2 := m.hash2(key)
+	if m.table2[pos2].filled && m.table2[pos2].key == key {
+		m.table2[pos2].value = val
+		return true, false
+	}
+
+	// Keep track of the path of displacements
+	type displacement struct {
+		isTable1 bool
+		position int
+	}
+	path := make([]displacement, 0, 2*m.maxKicks)
+
+	current := bucket[K, V]{filled: true, key: key, value: val}
+	for range m.maxKicks {
+		// Try table1
+		pos1 := m.hash1(current.key)
+		path = append(path, displacement{isTable1: true, position: pos1})
+		current, m.table1[pos1] = m.table1[pos1], current
+		if !current.filled {
+			return true, true
+		}
+
+		// Try table2
+		pos2 := m.hash2(current.key)
+		path = append(path, displacement{isTable1: false, position: pos2})
+		current, m.table2[pos2] = m.table2[pos2], current
+		if !current.filled {
+			return true, true
+		}
+	}
+
+	// Insertion failed, restore the original state by walking back the path
+	for i := len(path) - 1; i >= 0; i-- {
+		d := path[i]
+		if d.isTable1 {
+			current, m.table1[d.position] = m.table1[d.position], current
+		} else {
+			current, m.table2[d.position] = m.table2[d.position], current
+		}
+	}
+
+	return false, false
+}
+
+// prepareMapRehash builds a new map of the given size holding every current
+// entry, or returns nil if they do not all fit
+func (m *CuckooMap[K, V]) prepareMapRehash(size int) *CuckooMap[K, V] {
+	m.mu.RLock()
+	type kv struct {
+		key K
+		val V
+	}
+	items := make([]kv, 0, m.count)
+	for _, b := range m.table1 {
+		if b.filled {
+			items = append(items, kv{b.key, b.value})
+		}
+	}
+	for _, b := range m.table2 {
+		if b.filled {
+			items = append(items, kv{b.key, b.value})
+		}
+	}
+	m.mu.RUnlock()
+
+	newMap := NewCuckooMapWithHasher[K, V](size, m.hasher)
+
+	for _, item := range items {
+		ok, _ := newMap.putWithoutRehash(item.key, item.val)
+		if !ok {
+			return nil
+		}
+		newMap.count++
+	}
+
+	return newMap
+}
+
+// doRehash performs the rehashing operation
+func (m *CuckooMap[K, V]) doRehash() bool {
+	for m.rehashing.Load() {
+		time.Sleep(time.Millisecond)
+	}
+
+	m.rehashing.Store(true)
+	defer m.rehashing.Store(false)
+
+	var prepared *CuckooMap[K, V]
+	size := m.size
+	for m.GetLoadFactor() >= 0.5 || prepared == nil {
+		size *= 2
+		prepared = m.prepareMapRehash(size)
+		if prepared == nil {
+			time.Sleep(time.Millisecond)
+		} else {
+			m.swapTables(prepared)
+		}
+	}
+
+	return true
+}
+
+func (m *CuckooMap[K, V]) swapTables(prepared *CuckooMap[K, V]) {
+	m.mu.Lock()
+	m.table1 = prepared.table1
+	m.table2 = prepared.table2
+	m.size = prepared.size
+	m.maxKicks = prepared.maxKicks
+	m.count = prepared.count
+	m.mu.Unlock()
+}
+
+// Put inserts or updates the value for key, returning whether it succeeded.
+func (m *CuckooMap[K, V]) Put(key K, val V) bool {
+	backoff := writeBackoffWhileRehashing
+	for m.rehashing.Load() {
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+
+	for range maxTryRehashing {
+		ok, inserted := m.putWithoutRehash(key, val)
+		if ok {
+			if inserted {
+				m.mu.Lock()
+				m.count++
+				m.mu.Unlock()
+			}
+			return true
+		}
+		m.doRehash()
+	}
+
+	return false
+}
+
+// Delete removes key from the map, returning whether it was present.
+func (m *CuckooMap[K, V]) Delete(key K) bool {
+	backoff := writeBackoffWhileRehashing
+	for m.rehashing.Load() {
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	pos1 := m.hash1(key)
+	if m.table1[pos1].filled && m.table1[pos1].key == key {
+		m.table1[pos1] = bucket[K, V]{}
+		m.count--
+		return true
+	}
+
+	pos2 := m.hash2(key)
+	if m.table2[pos2].filled && m.table2[pos2].key == key {
+		m.table2[pos2] = bucket[K, V]{}
+		m.count--
+		return true
+	}
+
+	return false
+}
+
+// Range calls f for every key/value pair in the map, stopping early if f
+// returns false.
+func (m *CuckooMap[K, V]) Range(f func(K, V) bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, b := range m.table1 {
+		if b.filled && !f(b.key, b.value) {
+			return
+		}
+	}
+	for _, b := range m.table2 {
+		if b.filled && !f(b.key, b.value) {
+			return
+		}
+	}
+}
+
+// GetLoadFactor returns the current load factor of the map
+func (m *CuckooMap[K, V]) GetLoadFactor() float64 {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return float64(m.count) / float64(m.size*2)
+}
+
+// GetSize returns the current size of each table
+func (m *CuckooMap[K, V]) GetSize() int {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.size
+}
+
+// GetCount returns the number of items in the map
+func (m *CuckooMap[K, V]) GetCount() int {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.count
+}
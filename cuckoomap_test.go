@@ -0,0 +1,73 @@
+package main
+
+import "testing"
+
+func TestCuckooMapPutGetDelete(t *testing.T) {
+	m := NewCuckooMap[string, int](8)
+
+	if !m.Put("a", 1) {
+		t.Fatal("Put(a, 1) failed")
+	}
+	if val, ok := m.Get("a"); !ok || val != 1 {
+		t.Fatalf("Get(a) = (%d, %v), want (1, true)", val, ok)
+	}
+
+	if !m.Delete("a") {
+		t.Fatal("Delete(a) returned false for a present key")
+	}
+	if _, ok := m.Get("a"); ok {
+		t.Fatal("Get(a) found a key after Delete")
+	}
+}
+
+func TestCuckooMapPutUpdateDoesNotInflateCount(t *testing.T) {
+	m := NewCuckooMap[string, int](8)
+
+	m.Put("a", 1)
+	m.Put("a", 2)
+
+	if got := m.GetCount(); got != 1 {
+		t.Fatalf("GetCount() = %d after two Puts of the same key, want 1", got)
+	}
+	if val, ok := m.Get("a"); !ok || val != 2 {
+		t.Fatalf("Get(a) = (%d, %v), want (2, true)", val, ok)
+	}
+}
+
+// intKeyHasher is a custom Hasher for int keys, distinct from the built-in
+// default, used to exercise NewCuckooMapWithHasher.
+type intKeyHasher struct{}
+
+func (intKeyHasher) Hash1(key int) uint64 { return uint64(key) }
+func (intKeyHasher) Hash2(key int) uint64 { return uint64(key) + 1 }
+
+func TestCuckooMapWithCustomHasher(t *testing.T) {
+	m := NewCuckooMapWithHasher[int, string](8, intKeyHasher{})
+
+	if !m.Put(42, "answer") {
+		t.Fatal("Put(42, ...) failed")
+	}
+	if val, ok := m.Get(42); !ok || val != "answer" {
+		t.Fatalf("Get(42) = (%q, %v), want (\"answer\", true)", val, ok)
+	}
+}
+
+func TestCuckooMapRehashPreservesContents(t *testing.T) {
+	m := NewCuckooMap[string, int](2)
+	keys := []string{"apple", "banana", "orange", "grape", "mango", "pear", "kiwi", "plum"}
+	for i, key := range keys {
+		if !m.Put(key, i) {
+			t.Fatalf("Put(%q, %d) failed", key, i)
+		}
+	}
+
+	for i, key := range keys {
+		val, ok := m.Get(key)
+		if !ok || val != i {
+			t.Errorf("after rehash, Get(%q) = (%d, %v), want (%d, true)", key, val, ok, i)
+		}
+	}
+	if got := m.GetCount(); got != len(keys) {
+		t.Errorf("GetCount() = %d, want %d", got, len(keys))
+	}
+}
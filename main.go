@@ -13,6 +13,13 @@ const (
 	writeBackoffWhileRehashing = time.Millisecond
 )
 
+// entry is one node of the insertion-order linked list threaded on top of
+// the two cuckoo tables, in the same spirit as Starlark's hashtable.
+type entry struct {
+	key        string
+	prev, next *entry
+}
+
 // CuckooHashTable represents the cuckoo hash table structure
 type CuckooHashTable struct {
 	table1    []string
@@ -22,31 +29,143 @@ type CuckooHashTable struct {
 	count     int          // Track number of items in the table
 	mu        sync.RWMutex // Add RWMutex for thread safety
 	rehashing atomic.Bool  // Flag to indicate rehashing in progress
+
+	entries   map[string]*entry // key -> its node in the insertion-order list
+	head      *entry            // first key inserted, or nil if empty
+	tail      *entry            // last key inserted, or nil if empty
+	tailLink  **entry           // address of the nil terminator following tail
+	itercount atomic.Int32      // number of live iterators
+	frozen    atomic.Bool       // once set, blocks all mutation
 }
 
 // NewCuckooHashTable creates a new cuckoo hash table with given size
 func NewCuckooHashTable(size int) *CuckooHashTable {
-	return &CuckooHashTable{
+	c := &CuckooHashTable{
 		table1:   make([]string, size),
 		table2:   make([]string, size),
 		size:     size,
 		maxKicks: size * 2, // Prevent infinite loops
 		count:    0,
+		entries:  make(map[string]*entry),
 	}
+	c.tailLink = &c.head
+	return c
 }
 
-// hash1 is the first hash function
-func (c *CuckooHashTable) hash1(key string) int {
+// checkMutable panics if the table is frozen or is currently being iterated
+// over, matching Starlark's invariant that a hashtable must not be mutated
+// while an iterator is live.
+func (c *CuckooHashTable) checkMutable(verb string) {
+	if c.frozen.Load() {
+		panic(fmt.Sprintf("cannot %s frozen cuckoo hash table", verb))
+	}
+	if c.itercount.Load() > 0 {
+		panic(fmt.Sprintf("cannot %s cuckoo hash table during iteration", verb))
+	}
+}
+
+// appendEntry links key onto the tail of the insertion-order list. The
+// caller must hold c.mu and must already know key is not present.
+func (c *CuckooHashTable) appendEntry(key string) {
+	e := &entry{key: key, prev: c.tail}
+	*c.tailLink = e
+	c.tailLink = &e.next
+	c.tail = e
+	c.entries[key] = e
+}
+
+// removeEntry unlinks key from the insertion-order list, if present. The
+// caller must hold c.mu.
+func (c *CuckooHashTable) removeEntry(key string) {
+	e, ok := c.entries[key]
+	if !ok {
+		return
+	}
+	delete(c.entries, key)
+
+	if e.prev != nil {
+		e.prev.next = e.next
+	} else {
+		c.head = e.next
+	}
+
+	if e.next != nil {
+		e.next.prev = e.prev
+	} else {
+		// e was the tail; the terminator now follows its predecessor.
+		c.tail = e.prev
+		if e.prev != nil {
+			c.tailLink = &e.prev.next
+		} else {
+			c.tailLink = &c.head
+		}
+	}
+}
+
+// Iterator walks a CuckooHashTable's keys in insertion order. Obtaining one
+// via Iter makes any mutation of the table panic until Done is called.
+type Iterator struct {
+	c    *CuckooHashTable
+	next *entry
+}
+
+// Iter returns an iterator over the table's keys in the order they were
+// inserted. The table must not be mutated while the iterator is live; call
+// Done when finished with it.
+func (c *CuckooHashTable) Iter() *Iterator {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	c.itercount.Add(1)
+	return &Iterator{c: c, next: c.head}
+}
+
+// Next advances the iterator, returning the next key and true, or ("",
+// false) once the iterator is exhausted.
+func (it *Iterator) Next() (string, bool) {
+	if it.next == nil {
+		return "", false
+	}
+	key := it.next.key
+	it.next = it.next.next
+	return key, true
+}
+
+// Done releases the iterator, allowing the table to be mutated again.
+func (it *Iterator) Done() {
+	it.c.itercount.Add(-1)
+}
+
+// Freeze marks the table as immutable. Once frozen, Insert, Remove, and
+// rehashing all panic, which makes Iter and Contains safe to call
+// concurrently without locking.
+func (c *CuckooHashTable) Freeze() {
+	c.frozen.Store(true)
+}
+
+// tableHash1 is the first hash function, parameterized on table size so
+// Snapshot can reuse it without a live *CuckooHashTable.
+func tableHash1(key string, size int) int {
 	h := fnv.New32a()
 	h.Write([]byte(key))
-	return int(h.Sum32()&0x7FFFFFFF) % c.size // Use bitwise AND to make positive
+	return int(h.Sum32()&0x7FFFFFFF) % size // Use bitwise AND to make positive
 }
 
-// hash2 is the second hash function
-func (c *CuckooHashTable) hash2(key string) int {
+// tableHash2 is the second hash function, parameterized on table size so
+// Snapshot can reuse it without a live *CuckooHashTable.
+func tableHash2(key string, size int) int {
 	h := fnv.New64a()
 	h.Write([]byte(key))
-	return int(h.Sum64()&0x7FFFFFFFFFFFFFFF) % c.size // Use bitwise AND to make positive
+	return int(h.Sum64()&0x7FFFFFFFFFFFFFFF) % size // Use bitwise AND to make positive
+}
+
+// hash1 is the first hash function
+func (c *CuckooHashTable) hash1(key string) int {
+	return tableHash1(key, c.size)
+}
+
+// hash2 is the second hash function
+func (c *CuckooHashTable) hash2(key string) int {
+	return tableHash2(key, c.size)
 }
 
 // insertWithoutRehash is a helper function that attempts insertion without triggering rehash
@@ -110,19 +229,13 @@ func (c *CuckooHashTable) insertWithoutRehash(key string) bool {
 
 // prepareRehash checks if rehashing is needed and prepares a new table if so
 func (c *CuckooHashTable) prepareRehash(size int) *CuckooHashTable {
-	// Take a snapshot of the current table while holding the lock
+	// Take a snapshot of the current table while holding the lock. Walk the
+	// insertion-order list rather than the raw slots so the new table
+	// preserves the same order.
 	c.mu.RLock()
-	// Create copies of the current tables
 	items := make([]string, 0, c.count)
-	for _, item := range c.table1 {
-		if item != "" {
-			items = append(items, item)
-		}
-	}
-	for _, item := range c.table2 {
-		if item != "" {
-			items = append(items, item)
-		}
+	for e := c.head; e != nil; e = e.next {
+		items = append(items, e.key)
 	}
 	c.mu.RUnlock()
 
@@ -134,6 +247,7 @@ func (c *CuckooHashTable) prepareRehash(size int) *CuckooHashTable {
 		if !newTable.insertWithoutRehash(item) {
 			return nil
 		}
+		newTable.appendEntry(item)
 		newTable.count++
 	}
 
@@ -142,6 +256,8 @@ func (c *CuckooHashTable) prepareRehash(size int) *CuckooHashTable {
 
 // doRehash performs the rehashing operation
 func (c *CuckooHashTable) doRehash() bool {
+	c.checkMutable("rehash")
+
 	// If already rehashing, wait for it to complete
 	for c.rehashing.Load() {
 		time.Sleep(time.Millisecond)
@@ -178,12 +294,23 @@ func (c *CuckooHashTable) swapTables(prepared *CuckooHashTable) {
 	c.size = prepared.size
 	c.maxKicks = prepared.maxKicks
 	c.count = prepared.count
+	c.entries = prepared.entries
+	c.head = prepared.head
+	c.tail = prepared.tail
+	c.tailLink = prepared.tailLink
+	if c.head == nil {
+		// prepared.tailLink pointed at prepared's own head field; repoint it
+		// at ours now that prepared has been discarded.
+		c.tailLink = &c.head
+	}
 	c.mu.Unlock()
 	fmt.Println("Rehash completed")
 }
 
 // Insert adds a key to the hash table
 func (c *CuckooHashTable) Insert(key string) bool {
+	c.checkMutable("insert into")
+
 	backoff := writeBackoffWhileRehashing
 	for c.rehashing.Load() {
 		time.Sleep(backoff)
@@ -192,7 +319,12 @@ func (c *CuckooHashTable) Insert(key string) bool {
 
 	for range maxTryRehashing {
 		if c.insertWithoutRehash(key) {
-			c.count += 1
+			c.mu.Lock()
+			if _, exists := c.entries[key]; !exists {
+				c.appendEntry(key)
+				c.count++
+			}
+			c.mu.Unlock()
 			return true
 		}
 		c.doRehash()
@@ -210,6 +342,8 @@ func (c *CuckooHashTable) Contains(key string) bool {
 
 // Remove deletes a key from the hash table
 func (c *CuckooHashTable) Remove(key string) bool {
+	c.checkMutable("delete from")
+
 	backoff := writeBackoffWhileRehashing
 	for c.rehashing.Load() {
 		time.Sleep(backoff)
@@ -222,6 +356,7 @@ func (c *CuckooHashTable) Remove(key string) bool {
 	pos1 := c.hash1(key)
 	if c.table1[pos1] == key {
 		c.table1[pos1] = ""
+		c.removeEntry(key)
 		c.count--
 		return true
 	}
@@ -229,6 +364,7 @@ func (c *CuckooHashTable) Remove(key string) bool {
 	pos2 := c.hash2(key)
 	if c.table2[pos2] == key {
 		c.table2[pos2] = ""
+		c.removeEntry(key)
 		c.count--
 		return true
 	}
@@ -0,0 +1,89 @@
+package main
+
+import "testing"
+
+func TestIterInsertionOrder(t *testing.T) {
+	table := NewCuckooHashTable(8)
+	keys := []string{"one", "two", "three", "four"}
+	for _, key := range keys {
+		if !table.Insert(key) {
+			t.Fatalf("Insert(%q) failed", key)
+		}
+	}
+
+	it := table.Iter()
+	defer it.Done()
+
+	var got []string
+	for {
+		key, ok := it.Next()
+		if !ok {
+			break
+		}
+		got = append(got, key)
+	}
+
+	if len(got) != len(keys) {
+		t.Fatalf("got %d keys, want %d: %v", len(got), len(keys), got)
+	}
+	for i, key := range keys {
+		if got[i] != key {
+			t.Errorf("position %d: got %q, want %q", i, got[i], key)
+		}
+	}
+}
+
+func TestInsertDuplicateDoesNotInflateCount(t *testing.T) {
+	table := NewCuckooHashTable(8)
+
+	table.Insert("a")
+	table.Insert("a")
+
+	if got := table.GetCount(); got != 1 {
+		t.Fatalf("GetCount() = %d after two Inserts of the same key, want 1", got)
+	}
+}
+
+func TestRehashPreservesInsertionOrder(t *testing.T) {
+	table := NewCuckooHashTable(2)
+	keys := []string{"apple", "banana", "orange", "grape", "mango", "pear", "kiwi", "plum"}
+	for _, key := range keys {
+		if !table.Insert(key) {
+			t.Fatalf("Insert(%q) failed", key)
+		}
+	}
+
+	it := table.Iter()
+	defer it.Done()
+
+	var got []string
+	for {
+		key, ok := it.Next()
+		if !ok {
+			break
+		}
+		got = append(got, key)
+	}
+
+	if len(got) != len(keys) {
+		t.Fatalf("got %d keys after rehash, want %d: %v", len(got), len(keys), got)
+	}
+	for i, key := range keys {
+		if got[i] != key {
+			t.Errorf("position %d: got %q, want %q", i, got[i], key)
+		}
+	}
+}
+
+func TestFreezeBlocksMutation(t *testing.T) {
+	table := NewCuckooHashTable(4)
+	table.Insert("a")
+	table.Freeze()
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("Insert on a frozen table did not panic")
+		}
+	}()
+	table.Insert("b")
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+const (
+	snapshotMagic   = "CKHT"
+	snapshotVersion = uint32(1)
+)
+
+// Snapshot is an immutable, point-in-time copy of a CuckooHashTable's
+// tables, safe to read concurrently with ongoing inserts, removes, and
+// rehashes on the live table.
+type Snapshot struct {
+	table1 []string
+	table2 []string
+	size   int
+	count  int
+}
+
+// Snapshot copies c's tables under the read lock and returns them as an
+// independent, immutable Snapshot.
+func (c *CuckooHashTable) Snapshot() *Snapshot {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	s := &Snapshot{
+		table1: make([]string, len(c.table1)),
+		table2: make([]string, len(c.table2)),
+		size:   c.size,
+		count:  c.count,
+	}
+	copy(s.table1, c.table1)
+	copy(s.table2, c.table2)
+	return s
+}
+
+// Contains reports whether key was present when the snapshot was taken.
+func (s *Snapshot) Contains(key string) bool {
+	if s.table1[tableHash1(key, s.size)] == key {
+		return true
+	}
+	return s.table2[tableHash2(key, s.size)] == key
+}
+
+// Len returns the number of keys present when the snapshot was taken.
+func (s *Snapshot) Len() int {
+	return s.count
+}
+
+// Range calls f for every key in the snapshot, stopping early if f returns
+// false.
+func (s *Snapshot) Range(f func(key string) bool) {
+	for _, key := range s.table1 {
+		if key != "" && !f(key) {
+			return
+		}
+	}
+	for _, key := range s.table2 {
+		if key != "" && !f(key) {
+			return
+		}
+	}
+}
+
+// countingWriter tracks the total bytes written through it, so WriteTo can
+// report an accurate byte count even if it fails partway through.
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (cw *countingWriter) Write(p []byte) (int, error) {
+	n, err := cw.w.Write(p)
+	cw.n += int64(n)
+	return n, err
+}
+
+// WriteTo serializes the snapshot to w in a versioned binary format: magic,
+// version, size, count, then each table's keys in slot order,
+// length-prefixed, with empty slots encoded as a zero length.
+func (s *Snapshot) WriteTo(w io.Writer) (int64, error) {
+	cw := &countingWriter{w: w}
+
+	if _, err := cw.Write([]byte(snapshotMagic)); err != nil {
+		return cw.n, err
+	}
+	if err := binary.Write(cw, binary.BigEndian, snapshotVersion); err != nil {
+		return cw.n, err
+	}
+	if err := binary.Write(cw, binary.BigEndian, uint64(s.size)); err != nil {
+		return cw.n, err
+	}
+	if err := binary.Write(cw, binary.BigEndian, uint64(s.count)); err != nil {
+		return cw.n, err
+	}
+
+	for _, table := range [][]string{s.table1, s.table2} {
+		for _, key := range table {
+			if err := binary.Write(cw, binary.BigEndian, uint32(len(key))); err != nil {
+				return cw.n, err
+			}
+			if len(key) == 0 {
+				continue
+			}
+			if _, err := cw.Write([]byte(key)); err != nil {
+				return cw.n, err
+			}
+		}
+	}
+
+	return cw.n, nil
+}
+
+// LoadCuckooHashTable reads a table previously written by Snapshot.WriteTo.
+// The insertion order of the loaded table reflects the slot order the
+// snapshot was saved in, not the original insertion order, since that
+// ordering isn't part of the serialized format.
+func LoadCuckooHashTable(r io.Reader) (*CuckooHashTable, error) {
+	magic := make([]byte, len(snapshotMagic))
+	if _, err := io.ReadFull(r, magic); err != nil {
+		return nil, fmt.Errorf("cuckoohashtable: reading magic: %w", err)
+	}
+	if string(magic) != snapshotMagic {
+		return nil, fmt.Errorf("cuckoohashtable: bad magic %q", magic)
+	}
+
+	var version uint32
+	if err := binary.Read(r, binary.BigEndian, &version); err != nil {
+		return nil, fmt.Errorf("cuckoohashtable: reading version: %w", err)
+	}
+	if version != snapshotVersion {
+		return nil, fmt.Errorf("cuckoohashtable: unsupported snapshot version %d", version)
+	}
+
+	var size, count uint64
+	if err := binary.Read(r, binary.BigEndian, &size); err != nil {
+		return nil, fmt.Errorf("cuckoohashtable: reading size: %w", err)
+	}
+	if err := binary.Read(r, binary.BigEndian, &count); err != nil {
+		return nil, fmt.Errorf("cuckoohashtable: reading count: %w", err)
+	}
+
+	c := NewCuckooHashTable(int(size))
+	c.count = int(count)
+
+	for _, table := range [][]string{c.table1, c.table2} {
+		for i := range table {
+			var keyLen uint32
+			if err := binary.Read(r, binary.BigEndian, &keyLen); err != nil {
+				return nil, fmt.Errorf("cuckoohashtable: reading key length: %w", err)
+			}
+			if keyLen == 0 {
+				continue
+			}
+			buf := make([]byte, keyLen)
+			if _, err := io.ReadFull(r, buf); err != nil {
+				return nil, fmt.Errorf("cuckoohashtable: reading key: %w", err)
+			}
+			table[i] = string(buf)
+			c.appendEntry(table[i])
+		}
+	}
+
+	return c, nil
+}
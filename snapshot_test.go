@@ -0,0 +1,70 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestSnapshotContainsAndRange(t *testing.T) {
+	table := NewCuckooHashTable(8)
+	keys := []string{"one", "two", "three"}
+	for _, key := range keys {
+		table.Insert(key)
+	}
+
+	snap := table.Snapshot()
+	if snap.Len() != len(keys) {
+		t.Fatalf("Len() = %d, want %d", snap.Len(), len(keys))
+	}
+	for _, key := range keys {
+		if !snap.Contains(key) {
+			t.Errorf("Contains(%q) = false", key)
+		}
+	}
+
+	var seen int
+	snap.Range(func(key string) bool {
+		seen++
+		return true
+	})
+	if seen != len(keys) {
+		t.Errorf("Range visited %d keys, want %d", seen, len(keys))
+	}
+}
+
+func TestSnapshotWriteToLoadRoundTrip(t *testing.T) {
+	table := NewCuckooHashTable(8)
+	keys := []string{"apple", "banana", "orange", "grape"}
+	for _, key := range keys {
+		table.Insert(key)
+	}
+
+	var buf bytes.Buffer
+	if _, err := table.Snapshot().WriteTo(&buf); err != nil {
+		t.Fatalf("WriteTo: %v", err)
+	}
+
+	loaded, err := LoadCuckooHashTable(&buf)
+	if err != nil {
+		t.Fatalf("LoadCuckooHashTable: %v", err)
+	}
+
+	if got := loaded.GetCount(); got != len(keys) {
+		t.Fatalf("GetCount() = %d, want %d", got, len(keys))
+	}
+	if got := loaded.GetSize(); got != table.GetSize() {
+		t.Fatalf("GetSize() = %d, want %d", got, table.GetSize())
+	}
+	for _, key := range keys {
+		if !loaded.Contains(key) {
+			t.Errorf("Contains(%q) = false after round trip", key)
+		}
+	}
+}
+
+func TestLoadCuckooHashTableRejectsBadMagic(t *testing.T) {
+	_, err := LoadCuckooHashTable(bytes.NewReader([]byte("nope")))
+	if err == nil {
+		t.Fatal("LoadCuckooHashTable succeeded on bad magic, want error")
+	}
+}